@@ -0,0 +1,83 @@
+package mangler
+
+import (
+	"reflect"
+	"sync"
+)
+
+// nilIfaceMarker is appended, on its own, for a nil interface value. It can
+// never collide with a real concrete type tag, as those are always
+// followed by a ':' separator before any field bytes.
+const nilIfaceMarker = 0x00
+
+// concreteMangler is the lazily-resolved mangler for a single concrete type
+// seen behind an interface value, along with the stable tag written ahead
+// of its mangled bytes.
+type concreteMangler struct {
+	tag  string
+	mng  Mangler
+	rmng rMangler
+}
+
+// ifaceConcreteCache caches the concreteMangler resolved for each concrete
+// type seen stored in an interface-kind field or map value, so a concrete
+// type is only ever resolved once no matter how many interface values end
+// up holding it.
+var ifaceConcreteCache sync.Map // map[reflect.Type]*concreteMangler
+
+// loadReflectIface returns an rMangler for a reflect.Interface-kind type.
+// At mangle time it unwraps the concrete value held by the interface and
+// dispatches to a lazily-loaded, per-concrete-type mangler, prefixing the
+// output with a short stable tag (the concrete type's fully-qualified
+// name) so that two different concrete types stored in the same interface
+// field produce different mangled bytes.
+func loadReflectIface() rMangler {
+	return func(buf []byte, v reflect.Value) []byte {
+		if v.IsNil() {
+			return append(buf, nilIfaceMarker)
+		}
+
+		v = v.Elem()
+		cm := loadConcrete(v.Type())
+
+		buf = append(buf, cm.tag...)
+		buf = append(buf, ':')
+
+		if cm.rmng != nil {
+			return cm.rmng(buf, v)
+		}
+		return cm.mng(buf, v.Interface())
+	}
+}
+
+// loadConcrete resolves (and caches) the mangler to use for a concrete type
+// seen behind an interface value.
+func loadConcrete(t reflect.Type) *concreteMangler {
+	if cached, ok := ifaceConcreteCache.Load(t); ok {
+		return cached.(*concreteMangler)
+	}
+
+	mng, rmng := load(nil, t)
+	if mng == nil && rmng == nil {
+		panic("mangler: cannot mangle type: " + t.String())
+	}
+
+	cm := &concreteMangler{tag: concreteTypeTag(t), mng: mng, rmng: rmng}
+
+	actual, _ := ifaceConcreteCache.LoadOrStore(t, cm)
+	return actual.(*concreteMangler)
+}
+
+// concreteTypeTag returns a stable, collision-resistant tag for a concrete
+// type seen behind an interface value. reflect.Type.String() is explicitly
+// documented as NOT guaranteed unique among distinct types (e.g. two
+// differently-imported packages both named "pkg" can produce the same
+// string), so a named type is tagged by its fully-qualified PkgPath+Name
+// instead. Unnamed types (e.g. anonymous structs, slices) have no
+// PkgPath/Name, so String() remains the fallback for those.
+func concreteTypeTag(t reflect.Type) string {
+	if name := t.Name(); name != "" {
+		return t.PkgPath() + "." + name
+	}
+	return t.String()
+}
@@ -0,0 +1,70 @@
+package mangler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadReflectIfaceAny(t *testing.T) {
+	type holder struct {
+		V any
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(holder{}))
+
+	a := rmng(nil, reflect.ValueOf(holder{V: "hello"}))
+	b := rmng(nil, reflect.ValueOf(holder{V: 123}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("different concrete types stored in the same any field mangled identically: %v", a)
+	}
+}
+
+func TestLoadReflectIfaceNil(t *testing.T) {
+	type holder struct {
+		V any
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(holder{}))
+	got := rmng(nil, reflect.ValueOf(holder{V: nil}))
+
+	// the struct field plan writes "\xFFV:" ahead of the field's own bytes
+	// and a trailing fieldSep after, so the nil interface marker is the
+	// second-to-last byte written.
+	if marker := got[len(got)-2]; marker != nilIfaceMarker {
+		t.Fatalf("expected nil interface marker 0x%02x second-to-last, got %v", nilIfaceMarker, got)
+	}
+}
+
+type namer interface {
+	Name() string
+}
+
+type person struct{ PersonName string }
+
+func (p person) Name() string { return p.PersonName }
+
+func TestLoadReflectIfaceNamedInterface(t *testing.T) {
+	type holder struct {
+		V namer
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(holder{}))
+
+	a := rmng(nil, reflect.ValueOf(holder{V: person{PersonName: "a"}}))
+	b := rmng(nil, reflect.ValueOf(holder{V: person{PersonName: "b"}}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("differing concrete values behind a named interface mangled identically: %v", a)
+	}
+}
+
+func TestLoadReflectIfaceMapValue(t *testing.T) {
+	m := map[string]any{"a": 1, "b": "two"}
+
+	rmng := loadReflectMap(reflect.TypeOf(""), reflect.TypeOf((*any)(nil)).Elem())
+
+	got1 := rmng(nil, reflect.ValueOf(m))
+	got2 := rmng(nil, reflect.ValueOf(m))
+	if !reflect.DeepEqual(got1, got2) {
+		t.Fatalf("mangling a map with interface-typed values was not deterministic: %v != %v", got1, got2)
+	}
+}
@@ -47,6 +47,12 @@ func load(a any, t reflect.Type) (Mangler, rMangler) {
 		return mng, nil
 	}
 
+	// Check user-registered interface / reflect checks (RegisterInterface,
+	// RegisterReflect, and the opt-in UseText / UseStringer / UseJSON)
+	if mng, rmng, ok := loadRegistered(a, t); ok {
+		return mng, rmng
+	}
+
 	// Search by reflection
 	return loadReflect(t)
 }
@@ -110,6 +116,12 @@ func loadReflect(t reflect.Type) (Mangler, rMangler) {
 	case reflect.Map:
 		return nil, loadReflectMap(t.Key(), t.Elem())
 
+	case reflect.Struct:
+		return nil, loadReflectStruct(t)
+
+	case reflect.Interface:
+		return nil, loadReflectIface()
+
 	case reflect.Bool:
 		return mangle_bool, nil
 
@@ -164,7 +176,7 @@ func loadReflectPtr(et reflect.Type) (Mangler, rMangler) {
 
 	if et.Kind() == reflect.Array {
 		// Special case of addressable (sliceable) array
-		if mng := loadReflectKnownSlice(et); mng != nil {
+		if mng := loadReflectKnownSlice(et.Elem()); mng != nil {
 			if count == 1 {
 				return mng, nil
 			}
@@ -248,7 +260,15 @@ func loadReflectKnownPtr(et reflect.Type) Mangler {
 }
 
 // loadReflectKnownSlice loads a Mangler function for a known slice-of-element type (in this case, primtives).
+// This is also used for the addressable (sliceable) array special case in loadReflectPtr.
 func loadReflectKnownSlice(et reflect.Type) Mangler {
+	if EnableUnsafe {
+		// Prefer a direct memcpy of the backing bytes over looping per-element.
+		if mng := unsafeFixedWidthSliceMangler(et); mng != nil {
+			return mng
+		}
+	}
+
 	switch et.Kind() {
 	case reflect.String:
 		return mangle_string_slice
@@ -349,6 +369,6 @@ func loadReflectMap(kt, vt reflect.Type) rMangler {
 		return nil
 	}
 
-	// Wrap key/value manglers in map iter
-	return iter_map_rmangler(kmng, vmng)
+	// Wrap key/value manglers in a deterministic, order-independent map iter
+	return sortedMapMangler(kmng, vmng)
 }
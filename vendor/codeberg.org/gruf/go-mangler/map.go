@@ -0,0 +1,85 @@
+package mangler
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// mapPair is a mangled-key/value pair, used as scratch space while sorting
+// a map's entries into a deterministic order.
+type mapPair struct {
+	k []byte
+	v reflect.Value
+}
+
+// mapPairPool pools the []mapPair scratch slices used to sort map entries,
+// so the backing array of the scratch slice is reused across calls rather
+// than reallocated for every mangled map. Each key is still mangled into
+// its own newly allocated buffer.
+var mapPairPool = sync.Pool{
+	New: func() any { return make([]mapPair, 0, 8) },
+}
+
+// kvSep is written between a pair's mangled key and value, and again after
+// the value, so that for keys and values not containing kvSep bytes neither
+// a variable-length key/value boundary nor a pair boundary can shift:
+// without it, e.g. map[string]string{"a": "bc"} and
+// map[string]string{"ab": "c"} would both mangle to "abc". Keys and values
+// are not escaped, so ones containing kvSep bytes can still collide.
+const kvSep = 0xFE
+
+// sortedMapMangler returns an rMangler that mangles a map by first mangling
+// each key into a scratch buffer, sorting the resulting (key, value) pairs
+// by mangled key bytes, then emitting "key|sep|value|sep" pairs in that
+// order.
+//
+// This is what makes two equal maps mangle to byte-identical output: Go
+// randomises map iteration order, so iterating and appending directly (see
+// unsortedMapMangler) can produce a different byte sequence, and therefore
+// a different cache key, from one call to the next.
+func sortedMapMangler(kmng, vmng rMangler) rMangler {
+	return func(buf []byte, v reflect.Value) []byte {
+		pairs := mapPairPool.Get().([]mapPair)[:0]
+
+		for iter := v.MapRange(); iter.Next(); {
+			pairs = append(pairs, mapPair{
+				k: kmng(nil, iter.Key()),
+				v: iter.Value(),
+			})
+		}
+
+		sort.Slice(pairs, func(i, j int) bool {
+			return bytes.Compare(pairs[i].k, pairs[j].k) < 0
+		})
+
+		for _, pair := range pairs {
+			buf = append(buf, pair.k...)
+			buf = append(buf, kvSep)
+			buf = vmng(buf, pair.v)
+			buf = append(buf, kvSep)
+		}
+
+		for i := range pairs {
+			pairs[i] = mapPair{}
+		}
+
+		mapPairPool.Put(pairs) //nolint:staticcheck // elements don't outlive this call
+		return buf
+	}
+}
+
+// unsortedMapMangler mangles a map by appending key/value pairs in whatever
+// order reflect.Value.MapRange() happens to yield them. Kept only as the
+// baseline for BenchmarkSortedMapMangler; sortedMapMangler is what's actually
+// wired into loadReflectMap.
+func unsortedMapMangler(kmng, vmng rMangler) rMangler {
+	return func(buf []byte, v reflect.Value) []byte {
+		for iter := v.MapRange(); iter.Next(); {
+			buf = kmng(buf, iter.Key())
+			buf = vmng(buf, iter.Value())
+		}
+		return buf
+	}
+}
@@ -0,0 +1,110 @@
+package mangler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedMapManglerDeterministic(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}
+	rmng := loadReflectMap(reflect.TypeOf(""), reflect.TypeOf(0))
+
+	var first []byte
+	for i := 0; i < 50; i++ {
+		out := rmng(nil, reflect.ValueOf(m))
+		if i == 0 {
+			first = out
+			continue
+		}
+		if !reflect.DeepEqual(first, out) {
+			t.Fatalf("mangling the same map produced different output on iteration %d: %v != %v", i, first, out)
+		}
+	}
+}
+
+func TestSortedMapManglerKeyValueBoundary(t *testing.T) {
+	rmng := loadReflectMap(reflect.TypeOf(""), reflect.TypeOf(""))
+
+	a := rmng(nil, reflect.ValueOf(map[string]string{"a": "bc"}))
+	b := rmng(nil, reflect.ValueOf(map[string]string{"ab": "c"}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("maps with a shifted key/value boundary mangled identically: %v", a)
+	}
+}
+
+func FuzzSortedMapManglerDeterministic(f *testing.F) {
+	f.Add("a", 1, "b", 2)
+	f.Fuzz(func(t *testing.T, k1 string, v1 int, k2 string, v2 int) {
+		if k1 == k2 {
+			return
+		}
+
+		m := map[string]int{k1: v1, k2: v2}
+		rmng := loadReflectMap(reflect.TypeOf(""), reflect.TypeOf(0))
+
+		a := rmng(nil, reflect.ValueOf(m))
+		b := rmng(nil, reflect.ValueOf(m))
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("mangling %v twice was not deterministic: %v != %v", m, a, b)
+		}
+	})
+}
+
+func TestSortedMapManglerStructKeys(t *testing.T) {
+	type key struct {
+		A int
+		B string
+	}
+
+	m := map[key]int{
+		{A: 1, B: "x"}: 1,
+		{A: 2, B: "y"}: 2,
+		{A: 3, B: "z"}: 3,
+	}
+
+	rmng := loadReflectMap(reflect.TypeOf(key{}), reflect.TypeOf(0))
+
+	a := rmng(nil, reflect.ValueOf(m))
+	b := rmng(nil, reflect.ValueOf(m))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("mangling the same struct-keyed map twice was not deterministic: %v != %v", a, b)
+	}
+}
+
+func benchmarkMap() map[string]int {
+	m := make(map[string]int, 64)
+	for i := 0; i < 64; i++ {
+		m[string(rune('a'+i%26))+string(rune('A'+i%26))] = i
+	}
+	return m
+}
+
+func BenchmarkSortedMapMangler(b *testing.B) {
+	kmng, _ := load(nil, reflect.TypeOf(""))
+	vmng, _ := load(nil, reflect.TypeOf(0))
+	kmngR := func(buf []byte, v reflect.Value) []byte { return kmng(buf, v.Interface()) }
+	vmngR := func(buf []byte, v reflect.Value) []byte { return vmng(buf, v.Interface()) }
+
+	rmng := sortedMapMangler(kmngR, vmngR)
+	v := reflect.ValueOf(benchmarkMap())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rmng(nil, v)
+	}
+}
+
+func BenchmarkUnsortedMapMangler(b *testing.B) {
+	kmng, _ := load(nil, reflect.TypeOf(""))
+	vmng, _ := load(nil, reflect.TypeOf(0))
+	kmngR := func(buf []byte, v reflect.Value) []byte { return kmng(buf, v.Interface()) }
+	vmngR := func(buf []byte, v reflect.Value) []byte { return vmng(buf, v.Interface()) }
+
+	rmng := unsortedMapMangler(kmngR, vmngR)
+	v := reflect.ValueOf(benchmarkMap())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rmng(nil, v)
+	}
+}
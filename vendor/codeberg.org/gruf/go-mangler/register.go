@@ -0,0 +1,141 @@
+package mangler
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ifaceCheck pairs a user-supplied interface check with the Mangler to use
+// for values it matches.
+type ifaceCheck struct {
+	check func(any) bool
+	mng   Mangler
+}
+
+// reflectCheck pairs a user-supplied reflect.Type check with the rMangler
+// to use for types it matches.
+type reflectCheck struct {
+	check func(reflect.Type) bool
+	rmng  rMangler
+}
+
+var (
+	registryMu      sync.Mutex
+	ifaceRegistry   []ifaceCheck
+	reflectRegistry []reflectCheck
+)
+
+// registeredResult is the memoised outcome of probing the registered checks
+// for a single reflect.Type, so that probing only ever happens once per type.
+type registeredResult struct {
+	mng  Mangler
+	rmng rMangler
+	ok   bool
+}
+
+var registeredCache sync.Map // map[reflect.Type]registeredResult
+
+// RegisterInterface registers a Mangler to use for any value for which
+// check(a) returns true. Registered checks are consulted after the
+// hardcoded fast-path type switch in loadIface, but before falling through
+// to reflection, letting callers extend the type switch without patching
+// this package. A match is memoised per reflect.Type, so check only runs
+// once per type.
+//
+// RegisterInterface is not safe to call concurrently with mangling; register
+// all checks during program initialisation.
+func RegisterInterface(check func(any) bool, mng Mangler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	ifaceRegistry = append(ifaceRegistry, ifaceCheck{check: check, mng: mng})
+}
+
+// RegisterReflect registers an rMangler to use for any reflect.Type for
+// which check(t) returns true. See RegisterInterface.
+func RegisterReflect(check func(reflect.Type) bool, rmng rMangler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	reflectRegistry = append(reflectRegistry, reflectCheck{check: check, rmng: rmng})
+}
+
+// loadRegistered consults the registered interface/reflect checks for type t
+// and example value a, memoising the result so the checks only run once per
+// type no matter how many values of that type get mangled.
+func loadRegistered(a any, t reflect.Type) (Mangler, rMangler, bool) {
+	if cached, ok := registeredCache.Load(t); ok {
+		r := cached.(registeredResult)
+		return r.mng, r.rmng, r.ok
+	}
+
+	registryMu.Lock()
+	ifaceChecks := ifaceRegistry
+	reflectChecks := reflectRegistry
+	registryMu.Unlock()
+
+	for _, c := range ifaceChecks {
+		if c.check(a) {
+			r := registeredResult{mng: c.mng, ok: true}
+			registeredCache.Store(t, r)
+			return r.mng, nil, true
+		}
+	}
+
+	for _, c := range reflectChecks {
+		if c.check(t) {
+			r := registeredResult{rmng: c.rmng, ok: true}
+			registeredCache.Store(t, r)
+			return nil, r.rmng, true
+		}
+	}
+
+	registeredCache.Store(t, registeredResult{})
+	return nil, nil, false
+}
+
+// UseText registers encoding.TextMarshaler as a recognised interface, using
+// a value's MarshalText() output as its mangled identity.
+func UseText() {
+	RegisterInterface(func(a any) bool {
+		_, ok := a.(encoding.TextMarshaler)
+		return ok
+	}, mangle_text)
+}
+
+// UseStringer registers fmt.Stringer as a recognised interface, using a
+// value's String() output as its mangled identity. Not registered by
+// default, as for many types the output is large and unwieldy; opt in if
+// your Stringer types make good, stable cache keys.
+func UseStringer() {
+	RegisterInterface(func(a any) bool {
+		_, ok := a.(fmt.Stringer)
+		return ok
+	}, mangle_stringer)
+}
+
+// UseJSON registers json.Marshaler as a recognised interface, using a
+// value's MarshalJSON() output as its mangled identity.
+func UseJSON() {
+	RegisterInterface(func(a any) bool {
+		_, ok := a.(json.Marshaler)
+		return ok
+	}, mangle_json)
+}
+
+func mangle_text(buf []byte, a any) []byte {
+	b, err := a.(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		panic("mangler: " + err.Error())
+	}
+	return append(buf, b...)
+}
+
+func mangle_json(buf []byte, a any) []byte {
+	b, err := a.(json.Marshaler).MarshalJSON()
+	if err != nil {
+		panic("mangler: " + err.Error())
+	}
+	return append(buf, b...)
+}
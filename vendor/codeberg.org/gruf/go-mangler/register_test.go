@@ -0,0 +1,57 @@
+package mangler
+
+import (
+	"reflect"
+	"testing"
+)
+
+type namedString string
+
+func TestRegisterInterface(t *testing.T) {
+	type marker struct{ namedString }
+
+	RegisterInterface(func(a any) bool {
+		_, ok := a.(marker)
+		return ok
+	}, func(buf []byte, a any) []byte {
+		return append(buf, "registered"...)
+	})
+
+	mng := loadMangler(marker{}, reflect.TypeOf(marker{}))
+	got := mng(nil, marker{})
+	if string(got) != "registered" {
+		t.Fatalf("expected registered Mangler to be used, got %q", got)
+	}
+}
+
+func TestRegisterReflect(t *testing.T) {
+	type reflectMarker struct{ A, B int }
+
+	RegisterReflect(func(rt reflect.Type) bool {
+		return rt == reflect.TypeOf(reflectMarker{})
+	}, func(buf []byte, v reflect.Value) []byte {
+		return append(buf, "reflect-registered"...)
+	})
+
+	mng := loadMangler(reflectMarker{}, reflect.TypeOf(reflectMarker{}))
+	got := mng(nil, reflectMarker{A: 1, B: 2})
+	if string(got) != "reflect-registered" {
+		t.Fatalf("expected registered rMangler to be used, got %q", got)
+	}
+}
+
+type textyType struct{ v string }
+
+func (t textyType) MarshalText() ([]byte, error) {
+	return []byte("text:" + t.v), nil
+}
+
+func TestUseText(t *testing.T) {
+	UseText()
+
+	mng := loadMangler(textyType{v: "x"}, reflect.TypeOf(textyType{}))
+	got := mng(nil, textyType{v: "x"})
+	if string(got) != "text:x" {
+		t.Fatalf("expected MarshalText() output, got %q", got)
+	}
+}
@@ -0,0 +1,213 @@
+package mangler
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldSep is written before every mangled field (and before a field's
+// name/tag label) so that e.g. struct{A, B string}{"", "x"} and
+// struct{A, B string}{"x", ""} can never collide on the boundary.
+const fieldSep = 0xFF
+
+// fieldMangler describes a single struct field's contribution to the
+// mangled output, resolved once up-front and reused for every value of
+// the struct's type.
+type fieldMangler struct {
+	name string // field name, or 'mangler' tag override
+	idx  int    // index into reflect.Type.Field()
+	mng  Mangler
+	rmng rMangler
+}
+
+// structFields is the field-plan for a single struct type: which fields
+// to mangle, in what order, and with which (already resolved) mangler.
+type structFields struct {
+	fields []fieldMangler
+}
+
+// structCache caches the per-type field plan so that t.NumField() and the
+// child mangler lookups only ever need to happen once per struct type,
+// following the same "reflect once, cache a func" approach codec libraries
+// like ugorji/go use for their per-type encoders. Safe under concurrent
+// first-use: whichever goroutine stores first wins, and the others just did
+// some redundant (but harmless) work building their own *structFields.
+var structCache sync.Map // map[reflect.Type]*structFields
+
+// loadReflectStruct loads (building and caching on first use) an rMangler
+// for the given struct type.
+func loadReflectStruct(t reflect.Type) rMangler {
+	return buildStruct(t, nil)
+}
+
+// buildStruct builds (or returns the already-cached) field plan for t,
+// given the set of struct types already being built earlier in this same
+// recursive call — i.e. scoped to this call stack, not shared across
+// goroutines. That distinction matters: two goroutines mangling a
+// brand-new struct type for the first time concurrently is ordinary
+// concurrent first-use, not a cycle, and must not panic either of them.
+// A genuine cycle only exists when a type reappears within one recursive
+// build, which `ancestors` (rebuilt fresh per call stack) is what detects.
+func buildStruct(t reflect.Type, ancestors map[reflect.Type]struct{}) rMangler {
+	if cached, ok := structCache.Load(t); ok {
+		return mangleStruct(cached.(*structFields))
+	}
+
+	if _, ok := ancestors[t]; ok {
+		panic("mangler: cyclic struct type: " + t.String())
+	}
+
+	// Copy-on-write: each recursive step gets its own set, so sibling
+	// fields of the same struct don't see each other's ancestors, and nor
+	// does an unrelated concurrent build of the same type.
+	next := make(map[reflect.Type]struct{}, len(ancestors)+1)
+	for a := range ancestors {
+		next[a] = struct{}{}
+	}
+	next[t] = struct{}{}
+
+	sf := &structFields{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath != "" && !f.Anonymous {
+			// unexported, non-embedded field: nothing to mangle
+			continue
+		}
+
+		name, skip := fieldTag(f)
+		if skip {
+			continue
+		}
+
+		if fm, ok := structTypedFieldMangler(i, name, f.Type, f.Anonymous, next); ok {
+			sf.fields = append(sf.fields, fm)
+			continue
+		}
+
+		mng, rmng := load(nil, f.Type)
+		if mng == nil && rmng == nil {
+			panic("mangler: cannot mangle type: " + f.Type.String())
+		}
+
+		sf.fields = append(sf.fields, fieldMangler{
+			name: name,
+			idx:  i,
+			mng:  mng,
+			rmng: rmng,
+		})
+	}
+
+	actual, _ := structCache.LoadOrStore(t, sf)
+	return mangleStruct(actual.(*structFields))
+}
+
+// structTypedFieldMangler handles a (possibly pointer-indirected) field
+// that is itself a struct, mangling it via the ancestor-tracked build as a
+// single nested field under the field's own label. This holds for embedded
+// fields too: an embedded struct's fields are not promoted into the outer
+// struct's field list. Passing `ancestors` through here, rather than
+// going back via the generic load(), is what lets mutually-recursive
+// struct pointer fields be caught as a cycle local to this build instead
+// of racing against unrelated concurrent builds of the same type.
+//
+// A named (non-embedded) struct-typed field first gets the same chance as
+// any other field to match loadIface's hardcoded type switch or a
+// registered interface/reflect check — e.g. a named time.Time field still
+// needs to mangle via mangle_time, not by recursing into time.Time's own
+// (unexported) struct layout. Embedded fields skip those checks.
+func structTypedFieldMangler(idx int, name string, t reflect.Type, anonymous bool, ancestors map[reflect.Type]struct{}) (fieldMangler, bool) {
+	if !anonymous {
+		a := reflect.New(t).Elem().Interface()
+
+		if mng := loadIface(a); mng != nil {
+			return fieldMangler{name: name, idx: idx, mng: mng}, true
+		}
+
+		if mng, rmng, ok := loadRegistered(a, t); ok {
+			return fieldMangler{name: name, idx: idx, mng: mng, rmng: rmng}, true
+		}
+	}
+
+	dt := t
+	derefs := 0
+	for dt.Kind() == reflect.Pointer {
+		dt = dt.Elem()
+		derefs++
+	}
+
+	if dt.Kind() != reflect.Struct {
+		return fieldMangler{}, false
+	}
+
+	rmng := buildStruct(dt, ancestors)
+	if derefs > 0 {
+		rmng = derefStructMangler(rmng, derefs)
+	}
+
+	return fieldMangler{name: name, idx: idx, rmng: rmng}, true
+}
+
+// derefStructMangler wraps an embedded struct's rMangler to follow pointer
+// indirection, emitting a distinguished zero-byte marker for a nil pointer
+// anywhere along the chain.
+func derefStructMangler(rmng rMangler, derefs int) rMangler {
+	return func(buf []byte, v reflect.Value) []byte {
+		for i := 0; i < derefs; i++ {
+			if v.IsNil() {
+				return append(buf, 0x00)
+			}
+			v = v.Elem()
+		}
+		return rmng(buf, v)
+	}
+}
+
+// fieldTag parses the `mangler:"..."` struct tag for a field, returning the
+// label to use in the mangled output and whether the field should be
+// skipped entirely (tag value "-").
+func fieldTag(f reflect.StructField) (name string, skip bool) {
+	tag, ok := f.Tag.Lookup("mangler")
+	if !ok {
+		return f.Name, false
+	}
+
+	tag = strings.TrimSpace(tag)
+
+	switch tag {
+	case "-":
+		return "", true
+	case "":
+		return f.Name, false
+	default:
+		return tag, false
+	}
+}
+
+// mangleStruct returns an rMangler that iterates a cached field plan,
+// surrounding each field's mangled bytes with a stable separator on both
+// sides (in addition to the leading name/tag label), so that a payload
+// which does not itself contain fieldSep bytes cannot "absorb" part of its
+// neighbour's label or bytes across the field boundary. Payload bytes are
+// not escaped, so payloads containing fieldSep bytes can still collide.
+func mangleStruct(sf *structFields) rMangler {
+	return func(buf []byte, v reflect.Value) []byte {
+		for _, field := range sf.fields {
+			buf = append(buf, fieldSep)
+			buf = append(buf, field.name...)
+			buf = append(buf, ':')
+
+			fv := v.Field(field.idx)
+			if field.rmng != nil {
+				buf = field.rmng(buf, fv)
+			} else {
+				buf = field.mng(buf, fv.Interface())
+			}
+
+			buf = append(buf, fieldSep)
+		}
+		return buf
+	}
+}
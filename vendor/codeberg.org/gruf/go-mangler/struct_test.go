@@ -0,0 +1,177 @@
+package mangler
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadReflectStructNested(t *testing.T) {
+	type Inner struct {
+		Name string
+	}
+	type Outer struct {
+		Inner Inner
+		Count int
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(Outer{}))
+	v := reflect.ValueOf(Outer{Inner: Inner{Name: "a"}, Count: 1})
+
+	a := rmng(nil, v)
+	b := rmng(nil, v)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("mangling the same nested struct twice produced different output: %v != %v", a, b)
+	}
+
+	other := rmng(nil, reflect.ValueOf(Outer{Inner: Inner{Name: "b"}, Count: 1}))
+	if reflect.DeepEqual(a, other) {
+		t.Fatalf("differing nested struct field produced identical output")
+	}
+}
+
+func TestLoadReflectStructUnexported(t *testing.T) {
+	type withUnexported struct {
+		Exported   string
+		unexported string
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(withUnexported{}))
+
+	a := rmng(nil, reflect.ValueOf(withUnexported{Exported: "x", unexported: "1"}))
+	b := rmng(nil, reflect.ValueOf(withUnexported{Exported: "x", unexported: "2"}))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("unexported field changed mangled output: %v != %v", a, b)
+	}
+}
+
+func TestLoadReflectStructTag(t *testing.T) {
+	type tagged struct {
+		Skip    string `mangler:"-"`
+		Renamed string `mangler:"alias"`
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(tagged{}))
+
+	a := rmng(nil, reflect.ValueOf(tagged{Skip: "x", Renamed: "y"}))
+	b := rmng(nil, reflect.ValueOf(tagged{Skip: "different", Renamed: "y"}))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("mangler:\"-\" field changed mangled output: %v != %v", a, b)
+	}
+}
+
+func TestLoadReflectStructEmbedding(t *testing.T) {
+	type Base struct {
+		ID int
+	}
+	type withEmbed struct {
+		Base
+		Name string
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(withEmbed{}))
+
+	a := rmng(nil, reflect.ValueOf(withEmbed{Base: Base{ID: 1}, Name: "x"}))
+	b := rmng(nil, reflect.ValueOf(withEmbed{Base: Base{ID: 2}, Name: "x"}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("embedded field change was not reflected in mangled output")
+	}
+}
+
+// TestLoadReflectStructNamedFieldUsesLoadIface guards against a named
+// struct-typed field (as opposed to an embedded one) being built via
+// buildStruct instead of going through loadIface first: time.Time has only
+// unexported fields, so building it that way would make every value mangle
+// identically, silently erasing the field's contribution.
+func TestLoadReflectStructNamedFieldUsesLoadIface(t *testing.T) {
+	type withTime struct {
+		At time.Time
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(withTime{}))
+
+	a := rmng(nil, reflect.ValueOf(withTime{At: time.Unix(1, 0)}))
+	b := rmng(nil, reflect.ValueOf(withTime{At: time.Unix(2, 0)}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("differing named time.Time field produced identical output: %v", a)
+	}
+}
+
+// TestLoadReflectStructFieldBoundary reproduces a collision that was
+// previously possible across a field boundary: a leading-only fieldSep
+// lets a later field's label/bytes be "absorbed" into an earlier
+// variable-length field's payload.
+func TestLoadReflectStructFieldBoundary(t *testing.T) {
+	type S struct {
+		A string
+		B string
+	}
+
+	rmng := loadReflectStruct(reflect.TypeOf(S{}))
+
+	a := rmng(nil, reflect.ValueOf(S{A: "", B: "\xffB:bc"}))
+	b := rmng(nil, reflect.ValueOf(S{A: "\xffB:", B: "bc"}))
+	if reflect.DeepEqual(a, b) {
+		t.Fatalf("mangling across a shifted field boundary collided: %v", a)
+	}
+}
+
+func TestLoadReflectStructMutualPointerRecursion(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic mangling mutually-recursive struct types, got none")
+		}
+	}()
+
+	_ = loadReflectStruct(reflect.TypeOf(mutualA{}))
+}
+
+// mutualA and mutualB refer to each other via pointer fields, so building a
+// field plan for either recurses into the other until buildStruct sees a
+// type reappear among its ancestors and panics.
+type mutualA struct {
+	B *mutualB
+}
+
+type mutualB struct {
+	A *mutualA
+}
+
+// TestLoadReflectStructConcurrentFirstUse guards against ordinary concurrent
+// first-use of a brand-new struct type being mistaken for a cycle: every
+// goroutine here races to build the same never-before-seen type, and none
+// of them should panic.
+func TestLoadReflectStructConcurrentFirstUse(t *testing.T) {
+	type concurrentNew struct {
+		A int
+		B string
+	}
+
+	const n = 64
+
+	var wg sync.WaitGroup
+	panics := make(chan any, n)
+	wg.Add(n)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					panics <- r
+				}
+			}()
+
+			rmng := loadReflectStruct(reflect.TypeOf(concurrentNew{}))
+			rmng(nil, reflect.ValueOf(concurrentNew{A: 1, B: "x"}))
+		}()
+	}
+
+	wg.Wait()
+	close(panics)
+
+	for r := range panics {
+		t.Fatalf("unexpected panic mangling a brand-new struct type concurrently: %v", r)
+	}
+}
@@ -0,0 +1,71 @@
+package mangler
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// EnableUnsafe controls whether fixed-width numeric slices and arrays are
+// mangled by copying their backing bytes directly, rather than looping
+// element-by-element through a per-element mangler. It defaults to true;
+// set it to false before any mangling happens to opt out.
+var EnableUnsafe = true
+
+// fixedWidthSize returns the in-memory size of a single element of the
+// given kind, and whether that kind is one this package's unsafe fast path
+// knows how to copy directly (fixed-width integers, floats and complexes).
+func fixedWidthSize(k reflect.Kind) (size int, ok bool) {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 1, true
+	case reflect.Int16, reflect.Uint16:
+		return 2, true
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4, true
+	case reflect.Int64, reflect.Uint64, reflect.Float64, reflect.Complex64:
+		return 8, true
+	case reflect.Complex128:
+		return 16, true
+	default:
+		return 0, false
+	}
+}
+
+// unsafeFixedWidthSliceMangler returns a Mangler that appends a slice (or,
+// given a pointer to an addressable array, the array) of fixed-width
+// elements to the output buffer with a single append of its raw backing
+// bytes. This mirrors the arrayEncoder fast path used by binary encoders
+// such as encoding/gob, which reslice an array via
+// reflect.NewAt(typ, p).Elem().Slice(0, al) and write it in one shot rather
+// than visiting each element.
+//
+// Returns nil if et is not a kind the fast path supports, in which case
+// callers should fall back to the safe per-element mangler.
+func unsafeFixedWidthSliceMangler(et reflect.Type) Mangler {
+	size, ok := fixedWidthSize(et.Kind())
+	if !ok {
+		return nil
+	}
+
+	return func(buf []byte, a any) []byte {
+		v := reflect.ValueOf(a)
+
+		if v.Kind() == reflect.Pointer {
+			// Pointer to an addressable array: reslice it.
+			v = v.Elem()
+		}
+
+		if v.Kind() == reflect.Array {
+			v = v.Slice(0, v.Len())
+		}
+
+		n := v.Len()
+		if n == 0 {
+			return buf
+		}
+
+		data := unsafe.Pointer(v.Pointer())
+		raw := unsafe.Slice((*byte)(data), n*size)
+		return append(buf, raw...)
+	}
+}
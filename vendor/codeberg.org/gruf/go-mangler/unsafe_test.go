@@ -0,0 +1,89 @@
+package mangler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestUnsafeFixedWidthSliceManglerMatchesSafeSlice compares the unsafe fast
+// path against the real mangle_32bit_slice safe path it's meant to be an
+// optimisation of, not a self-authored re-encoding, so that a divergence in
+// byte layout (e.g. endianness) between the two would actually be caught.
+func TestUnsafeFixedWidthSliceManglerMatchesSafeSlice(t *testing.T) {
+	data := []int32{1, -2, 3, 1 << 20, -99999}
+
+	mng := unsafeFixedWidthSliceMangler(reflect.TypeOf(int32(0)))
+	if mng == nil {
+		t.Fatal("expected a mangler for int32 elements")
+	}
+
+	got := mng(nil, data)
+	want := mangle_32bit_slice(nil, data)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unsafe fast path diverged from the safe mangle_32bit_slice path: got %v, want %v", got, want)
+	}
+}
+
+// TestUnsafeFixedWidthSliceManglerMatchesSafeArrayPointer is the
+// pointer-to-array equivalent of TestUnsafeFixedWidthSliceManglerMatchesSafeSlice.
+func TestUnsafeFixedWidthSliceManglerMatchesSafeArrayPointer(t *testing.T) {
+	arr := [4]uint16{10, 20, 30, 40}
+
+	mng := unsafeFixedWidthSliceMangler(reflect.TypeOf(uint16(0)))
+	if mng == nil {
+		t.Fatal("expected a mangler for uint16 elements")
+	}
+
+	got := mng(nil, &arr)
+	want := mangle_16bit_slice(nil, &arr)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unsafe fast path diverged from the safe mangle_16bit_slice path: got %v, want %v", got, want)
+	}
+}
+
+func TestUnsafeFixedWidthSliceManglerUnsupportedKind(t *testing.T) {
+	if mng := unsafeFixedWidthSliceMangler(reflect.TypeOf("")); mng != nil {
+		t.Fatal("expected nil mangler for a non-fixed-width kind")
+	}
+}
+
+func TestLoadReflectKnownSliceRespectsEnableUnsafe(t *testing.T) {
+	old := EnableUnsafe
+	defer func() { EnableUnsafe = old }()
+
+	EnableUnsafe = false
+	mng := loadReflectKnownSlice(reflect.TypeOf(int32(0)))
+	if mng == nil {
+		t.Fatal("expected a fallback mangler with EnableUnsafe disabled")
+	}
+	if reflect.ValueOf(mng).Pointer() != reflect.ValueOf(mangle_32bit_slice).Pointer() {
+		t.Fatal("expected the safe mangle_32bit_slice mangler with EnableUnsafe disabled")
+	}
+}
+
+// TestLoadReflectPtrArrayUsesUnsafeFastPath exercises the actual
+// "addressable (sliceable) array" branch of loadReflectPtr, rather than
+// calling unsafeFixedWidthSliceMangler directly, to catch call-site
+// mismatches (e.g. passing the array type instead of its element type)
+// that the other tests in this file can't see.
+func TestLoadReflectPtrArrayUsesUnsafeFastPath(t *testing.T) {
+	old := EnableUnsafe
+	defer func() { EnableUnsafe = old }()
+	EnableUnsafe = true
+
+	arr := [4]uint16{10, 20, 30, 40}
+
+	mng, rmng := loadReflectPtr(reflect.TypeOf(arr))
+	if rmng != nil || mng == nil {
+		t.Fatalf("expected a direct Mangler for *[4]uint16, got mng=%v rmng=%v", mng, rmng)
+	}
+
+	got := mng(nil, &arr)
+	want := mangle_16bit_slice(nil, &arr)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("*[4]uint16 did not take the unsafe fast path: got %v, want %v", got, want)
+	}
+}